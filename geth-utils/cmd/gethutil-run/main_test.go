@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestLoadPrestate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prestate.json")
+	addr := common.BytesToAddress([]byte{0xaa})
+	content := `{
+		"` + addr.Hex() + `": {
+			"balance": "0x64",
+			"nonce": "0x2",
+			"code": "0x6001600101"
+		}
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write prestate file: %v", err)
+	}
+
+	accounts, err := loadPrestate(path)
+	if err != nil {
+		t.Fatalf("loadPrestate returned an error: %v", err)
+	}
+
+	account, ok := accounts[addr]
+	if !ok {
+		t.Fatalf("expected account %s to be present", addr)
+	}
+	if account.Balance.Int64() != 0x64 {
+		t.Fatalf("expected balance 0x64, got %s", account.Balance)
+	}
+	if account.Nonce != 2 {
+		t.Fatalf("expected nonce 2, got %d", account.Nonce)
+	}
+	if len(account.Code) == 0 {
+		t.Fatal("expected code to be decoded")
+	}
+}
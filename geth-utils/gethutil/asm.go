@@ -0,0 +1,66 @@
+package gethutil
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Assembly is a fluent builder for raw EVM bytecode. It exists so that
+// circuit test vectors can be written as a sequence of opcodes instead
+// of hand-packed byte slices, e.g. NewAssembly().MStore(0x40, 0x80).MSize().Stop().
+type Assembly struct {
+	code []byte
+}
+
+// NewAssembly returns an empty Assembly ready to be built up via chained
+// opcode calls.
+func NewAssembly() *Assembly {
+	return &Assembly{}
+}
+
+// Bytecode returns the bytecode accumulated so far.
+func (a *Assembly) Bytecode() []byte {
+	return a.code
+}
+
+func (a *Assembly) op(op vm.OpCode) *Assembly {
+	a.code = append(a.code, byte(op))
+	return a
+}
+
+// Push appends the smallest PUSH instruction that fits value, using a
+// single zero byte (PUSH1 0x00) for the zero value.
+func (a *Assembly) Push(value *big.Int) *Assembly {
+	b := value.Bytes()
+	if len(b) == 0 {
+		b = []byte{0}
+	}
+	if len(b) > 32 {
+		panic("gethutil: value does not fit in a PUSH32")
+	}
+
+	a.op(vm.OpCode(int(vm.PUSH1) + len(b) - 1))
+	a.code = append(a.code, b...)
+	return a
+}
+
+// PushInt is a convenience wrapper around Push for small integer literals.
+func (a *Assembly) PushInt(value int64) *Assembly {
+	return a.Push(big.NewInt(value))
+}
+
+// MStore emits the PUSH value, PUSH offset, MSTORE sequence.
+func (a *Assembly) MStore(offset, value int64) *Assembly {
+	return a.PushInt(value).PushInt(offset).op(vm.MSTORE)
+}
+
+// MSize emits MSIZE.
+func (a *Assembly) MSize() *Assembly {
+	return a.op(vm.MSIZE)
+}
+
+// Stop emits STOP.
+func (a *Assembly) Stop() *Assembly {
+	return a.op(vm.STOP)
+}
@@ -0,0 +1,112 @@
+package gethutil
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Instruction is a single decoded opcode: its program counter, the raw
+// opcode, and any PUSH immediate that followed it. Truncated is set when
+// a PUSH's immediate ran past the end of code, in which case Args holds
+// whatever bytes remained rather than the full immediate.
+type Instruction struct {
+	Pc        uint64
+	Op        vm.OpCode
+	Args      []byte
+	Truncated bool
+}
+
+// Instructions is a disassembled instruction stream, in program order.
+type Instructions []Instruction
+
+// Disassemble walks code and decodes it into a slice of Instruction,
+// pairing PUSH1-PUSH32 with their immediate bytes and annotating
+// JUMPDEST targets. It is the inverse of Assembly: Disassemble(a.Bytecode())
+// produces instructions whose Bytecode() reproduces a.Bytecode().
+//
+// A PUSH whose immediate runs past the end of code is reported as an
+// error rather than silently padded with zero bytes, since that would
+// misrepresent bytecode as shorter than it actually decodes to. Callers
+// that would rather keep going, e.g. to inspect a mutated or
+// hand-truncated contract, can use DisassembleTolerant instead.
+func Disassemble(code []byte) (Instructions, error) {
+	return disassemble(code, false)
+}
+
+// DisassembleTolerant disassembles code the same way as Disassemble,
+// except a truncated PUSH at the tail never errors: it is emitted as a
+// final Instruction with Truncated set and Args holding whatever bytes
+// remained, as a marker rather than a dropped instruction.
+func DisassembleTolerant(code []byte) Instructions {
+	instructions, _ := disassemble(code, true)
+	return instructions
+}
+
+func disassemble(code []byte, tolerant bool) (Instructions, error) {
+	var instructions Instructions
+	for pc := uint64(0); pc < uint64(len(code)); {
+		op := vm.OpCode(code[pc])
+		inst := Instruction{Pc: pc, Op: op}
+
+		if op >= vm.PUSH1 && op <= vm.PUSH32 {
+			size := uint64(op - vm.PUSH1 + 1)
+			available := uint64(len(code)) - pc - 1
+			if available < size {
+				if !tolerant {
+					return instructions, fmt.Errorf("truncated %s at pc %d: need %d bytes, have %d", op, pc, size, available)
+				}
+				inst.Args = code[pc+1:]
+				inst.Truncated = true
+				return append(instructions, inst), nil
+			}
+			inst.Args = code[pc+1 : pc+1+size]
+			pc += 1 + size
+		} else {
+			pc++
+		}
+
+		instructions = append(instructions, inst)
+	}
+	return instructions, nil
+}
+
+// Bytecode re-assembles instructions back into raw bytecode.
+func (instructions Instructions) Bytecode() []byte {
+	var code []byte
+	for _, inst := range instructions {
+		code = append(code, byte(inst.Op))
+		code = append(code, inst.Args...)
+	}
+	return code
+}
+
+// String renders the instructions the way go-ethereum's cmd/disasm does:
+// one line per instruction with its PC, hex opcode, mnemonic, and any
+// PUSH argument, with JUMPDEST targets called out so the listing can
+// double as a jump-target map.
+func (instructions Instructions) String() string {
+	var b strings.Builder
+	for _, inst := range instructions {
+		fmt.Fprintf(&b, "%05d: %02x %s", inst.Pc, byte(inst.Op), inst.Op)
+		if len(inst.Args) > 0 {
+			fmt.Fprintf(&b, " 0x%x", inst.Args)
+		}
+		if inst.Op == vm.JUMPDEST {
+			fmt.Fprint(&b, " <jumpdest>")
+		}
+		if inst.Truncated {
+			fmt.Fprint(&b, " <truncated>")
+		}
+		fmt.Fprintln(&b)
+	}
+	return b.String()
+}
+
+// Print writes the disassembly listing to w.
+func (instructions Instructions) Print(w io.Writer) error {
+	_, err := io.WriteString(w, instructions.String())
+	return err
+}
@@ -0,0 +1,42 @@
+package gethutil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestDisassembleRoundTrip(t *testing.T) {
+	code := NewAssembly().MStore(0x40, 0x80).MSize().Stop().Bytecode()
+
+	instructions, err := Disassemble(code)
+	if err != nil {
+		t.Fatalf("Disassemble returned an error: %v", err)
+	}
+
+	if got := instructions.Bytecode(); !bytes.Equal(got, code) {
+		t.Fatalf("re-assembled bytecode %x does not match original %x", got, code)
+	}
+}
+
+func TestDisassembleTruncatedPush(t *testing.T) {
+	// PUSH2 followed by a single byte: the immediate is cut short.
+	code := []byte{byte(vm.PUSH2), 0x01}
+
+	if _, err := Disassemble(code); err == nil {
+		t.Fatal("expected Disassemble to error on a truncated PUSH")
+	}
+
+	instructions := DisassembleTolerant(code)
+	if len(instructions) != 1 {
+		t.Fatalf("expected 1 instruction, got %d", len(instructions))
+	}
+	inst := instructions[0]
+	if !inst.Truncated {
+		t.Fatal("expected the truncated PUSH to be marked Truncated")
+	}
+	if !bytes.Equal(inst.Args, []byte{0x01}) {
+		t.Fatalf("expected remaining byte 0x01 as Args, got %x", inst.Args)
+	}
+}
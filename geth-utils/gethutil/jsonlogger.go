@@ -0,0 +1,134 @@
+package gethutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// StructLogHandler receives one callback per opcode as Trace executes,
+// rather than a fully materialized trace handed over at the end. It has
+// the same shape as go-ethereum's own EVMLogger interface, so a
+// TraceConfig.Logger is passed straight through as the vm.Config.Tracer
+// used by the underlying EVM.
+type StructLogHandler = vm.EVMLogger
+
+// jsonLogger is a StructLogHandler that streams one JSON object per
+// opcode to an io.Writer as execution proceeds, in the newline-delimited
+// format geth's own JSON logger produces, so it can be piped into jq or
+// a line-by-line trace differ without ever holding the full trace in
+// memory.
+type jsonLogger struct {
+	encoder *json.Encoder
+	env     *vm.EVM
+	// storage tracks, per contract, every slot touched so far by SLOAD
+	// or SSTORE, mirroring vm.StructLogger's running storage snapshot.
+	storage map[common.Address]map[common.Hash]common.Hash
+}
+
+// NewJSONLogger returns a StructLogHandler that writes one JSON object
+// per opcode to w as execution proceeds.
+func NewJSONLogger(w io.Writer) StructLogHandler {
+	return &jsonLogger{
+		encoder: json.NewEncoder(w),
+		storage: make(map[common.Address]map[common.Hash]common.Hash),
+	}
+}
+
+type jsonLogLine struct {
+	Pc      uint64            `json:"pc"`
+	Op      string            `json:"op"`
+	Gas     uint64            `json:"gas"`
+	GasCost uint64            `json:"gasCost"`
+	Depth   int               `json:"depth"`
+	Stack   []string          `json:"stack,omitempty"`
+	Memory  []string          `json:"memory,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+func (l *jsonLogger) CaptureStart(env *vm.EVM, from, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	l.env = env
+}
+
+func (l *jsonLogger) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	line := jsonLogLine{Pc: pc, Op: op.String(), Gas: gas, GasCost: cost, Depth: depth}
+
+	if scope != nil && scope.Stack != nil {
+		for _, v := range scope.Stack.Data() {
+			line.Stack = append(line.Stack, v.String())
+		}
+	}
+	if scope != nil && scope.Memory != nil {
+		data := scope.Memory.Data()
+		for i := 0; i < len(data); i += 32 {
+			end := i + 32
+			if end > len(data) {
+				end = len(data)
+			}
+			line.Memory = append(line.Memory, fmt.Sprintf("%x", data[i:end]))
+		}
+	}
+	if scope != nil && scope.Contract != nil {
+		line.Storage = l.captureStorage(scope, op)
+	}
+	if err != nil {
+		line.Error = err.Error()
+	}
+
+	// Encode errors are not actionable here: the logger has no return
+	// value in the EVMLogger interface, matching geth's own JSON logger.
+	_ = l.encoder.Encode(line)
+}
+
+// captureStorage records the slot an SLOAD/SSTORE just touched and
+// returns the current contract's accumulated storage, keyed by hex slot.
+func (l *jsonLogger) captureStorage(scope *vm.ScopeContext, op vm.OpCode) map[string]string {
+	addr := scope.Contract.Address()
+	stack := scope.Stack.Data()
+
+	switch {
+	case op == vm.SLOAD && len(stack) >= 1 && l.env != nil:
+		key := common.Hash(stack[len(stack)-1].Bytes32())
+		l.recordStorage(addr, key, l.env.StateDB.GetState(addr, key))
+	case op == vm.SSTORE && len(stack) >= 2:
+		key := common.Hash(stack[len(stack)-1].Bytes32())
+		value := common.Hash(stack[len(stack)-2].Bytes32())
+		l.recordStorage(addr, key, value)
+	}
+
+	slots := l.storage[addr]
+	if len(slots) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(slots))
+	for k, v := range slots {
+		out[k.Hex()] = v.Hex()
+	}
+	return out
+}
+
+func (l *jsonLogger) recordStorage(addr common.Address, key, value common.Hash) {
+	slots, ok := l.storage[addr]
+	if !ok {
+		slots = make(map[common.Hash]common.Hash)
+		l.storage[addr] = slots
+	}
+	slots[key] = value
+}
+
+func (l *jsonLogger) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	l.CaptureState(pc, op, gas, cost, scope, nil, depth, err)
+}
+
+func (l *jsonLogger) CaptureEnter(typ vm.OpCode, from, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+func (l *jsonLogger) CaptureExit(output []byte, gasUsed uint64, err error) {}
+
+func (l *jsonLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) {}
@@ -0,0 +1,40 @@
+package gethutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestNewJSONLoggerStreamsOneObjectPerOpcode(t *testing.T) {
+	address := common.BytesToAddress([]byte{0xff})
+	code := NewAssembly().MStore(0x40, 0x80).MSize().Stop().Bytecode()
+
+	var out bytes.Buffer
+	results, err := Trace(TraceConfig{
+		Accounts:     map[common.Address]Account{address: {Code: code}},
+		Transactions: []Transaction{{To: &address, GasLimit: 21100}},
+		Logger:       NewJSONLogger(&out),
+	})
+	if err != nil {
+		t.Fatalf("Trace returned an error: %v", err)
+	}
+	if len(results[0].StructLogs) != 0 {
+		t.Fatal("expected no buffered StructLogs when a Logger is supplied")
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		t.Fatal("expected at least one streamed line")
+	}
+	var first jsonLogLine
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("first streamed line is not valid JSON: %v", err)
+	}
+	if first.Op == "" {
+		t.Fatal("expected the first streamed line to report an opcode")
+	}
+}
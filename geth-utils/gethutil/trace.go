@@ -0,0 +1,291 @@
+package gethutil
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Account is the pre-execution state of a single address: its balance,
+// nonce, code, and storage slots.
+type Account struct {
+	Balance *big.Int
+	Nonce   uint64
+	Code    []byte
+	Storage map[common.Hash]common.Hash
+}
+
+// Transaction is the subset of transaction fields Trace needs to run a
+// call or contract creation. A nil To means contract creation; a nil
+// Value, GasPrice, GasFeeCap, or GasTipCap is treated as zero.
+//
+// When PrivateKey is set, Trace signs the transaction with the signer
+// matching ChainID and Type and recovers From from the signature,
+// overriding whatever From was set to. When PrivateKey is nil, Trace
+// builds a fake message that stamps From directly onto the call, the
+// way eth_call does for an unsigned sender.
+type Transaction struct {
+	From       common.Address
+	To         *common.Address
+	Value      *big.Int
+	Data       []byte
+	GasLimit   uint64
+	GasPrice   *big.Int
+	GasFeeCap  *big.Int
+	GasTipCap  *big.Int
+	Nonce      uint64
+	ChainID    *big.Int
+	AccessList types.AccessList
+	Type       uint8
+	PrivateKey *ecdsa.PrivateKey
+}
+
+// AccountDiff is the observable post-execution state of a single
+// address: its balance, nonce, and (for the storage keys that were
+// populated in the pre-state) its current values.
+type AccountDiff struct {
+	Balance *big.Int
+	Nonce   uint64
+	Storage map[common.Hash]common.Hash
+}
+
+// TraceConfig bundles the pre-state and transactions to execute. Accounts
+// seeds an in-memory state; Transactions run in order against that same
+// state, so state changes from transaction N are visible to N+1.
+//
+// If Logger is set, Trace invokes it once per opcode as execution
+// proceeds instead of accumulating StructLogs, so long-running traces
+// never have to be held in memory in full before a consumer sees them.
+type TraceConfig struct {
+	ChainConfig  *params.ChainConfig
+	Accounts     map[common.Address]Account
+	Transactions []Transaction
+	Logger       StructLogHandler
+}
+
+// ExecutionResult mirrors go-ethereum's debug_traceTransaction response
+// shape: gas used, whether the call reverted, the return data, the
+// opcode-by-opcode struct log, and the resulting post-state of every
+// address touched by the pre-state or the transaction itself.
+type ExecutionResult struct {
+	Gas         uint64
+	Failed      bool
+	ReturnValue string
+	StructLogs  []vm.StructLog
+	PostState   map[common.Address]AccountDiff
+}
+
+func newStateDB(accounts map[common.Address]Account) (*state.StateDB, error) {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for addr, account := range accounts {
+		if account.Balance != nil {
+			statedb.SetBalance(addr, account.Balance)
+		}
+		statedb.SetNonce(addr, account.Nonce)
+		statedb.SetCode(addr, account.Code)
+		for key, value := range account.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	return statedb, nil
+}
+
+// txSigner returns the go-ethereum signer matching a transaction's
+// declared type.
+func txSigner(chainID *big.Int, txType uint8) types.Signer {
+	switch txType {
+	case types.AccessListTxType:
+		return types.NewEIP2930Signer(chainID)
+	case types.DynamicFeeTxType:
+		return types.NewLondonSigner(chainID)
+	default:
+		return types.NewEIP155Signer(chainID)
+	}
+}
+
+// toMessage turns a Transaction into a go-ethereum core.Message: signed
+// with PrivateKey when present, or else a fake message that stamps From
+// directly onto the call. baseFee is forwarded to AsMessage so a signed
+// EIP-1559 transaction's effective gas price is computed from
+// GasFeeCap/GasTipCap/baseFee rather than defaulting to the full
+// GasFeeCap.
+func (tx Transaction) toMessage(chainConfig *params.ChainConfig, baseFee *big.Int) (types.Message, error) {
+	chainID := tx.ChainID
+	if chainID == nil {
+		chainID = chainConfig.ChainID
+	}
+	value := tx.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	gasPrice := tx.GasPrice
+	if gasPrice == nil {
+		gasPrice = new(big.Int)
+	}
+	gasFeeCap := tx.GasFeeCap
+	if gasFeeCap == nil {
+		gasFeeCap = gasPrice
+	}
+	gasTipCap := tx.GasTipCap
+	if gasTipCap == nil {
+		gasTipCap = gasPrice
+	}
+
+	if tx.PrivateKey == nil {
+		// isFake: true skips the nonce check in ApplyMessage's preCheck,
+		// the same way eth_call's doCall stamps From onto an unsigned
+		// message without requiring the sender's nonce to match.
+		return types.NewMessage(tx.From, tx.To, tx.Nonce, value, tx.GasLimit, gasPrice, gasFeeCap, gasTipCap, tx.Data, tx.AccessList, true), nil
+	}
+
+	var txData types.TxData
+	switch tx.Type {
+	case types.AccessListTxType:
+		txData = &types.AccessListTx{
+			ChainID: chainID, Nonce: tx.Nonce, GasPrice: gasPrice, Gas: tx.GasLimit,
+			To: tx.To, Value: value, Data: tx.Data, AccessList: tx.AccessList,
+		}
+	case types.DynamicFeeTxType:
+		txData = &types.DynamicFeeTx{
+			ChainID: chainID, Nonce: tx.Nonce, GasTipCap: gasTipCap, GasFeeCap: gasFeeCap, Gas: tx.GasLimit,
+			To: tx.To, Value: value, Data: tx.Data, AccessList: tx.AccessList,
+		}
+	default:
+		txData = &types.LegacyTx{Nonce: tx.Nonce, GasPrice: gasPrice, Gas: tx.GasLimit, To: tx.To, Value: value, Data: tx.Data}
+	}
+
+	signer := txSigner(chainID, tx.Type)
+	signed, err := types.SignNewTx(tx.PrivateKey, signer, txData)
+	if err != nil {
+		return types.Message{}, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	return signed.AsMessage(signer, baseFee)
+}
+
+// snapshotAccount captures the observable balance, nonce, and (for the
+// given storage keys) current storage of addr in statedb.
+func snapshotAccount(statedb *state.StateDB, addr common.Address, keys []common.Hash) AccountDiff {
+	diff := AccountDiff{Balance: statedb.GetBalance(addr), Nonce: statedb.GetNonce(addr)}
+	if len(keys) > 0 {
+		diff.Storage = make(map[common.Hash]common.Hash, len(keys))
+		for _, key := range keys {
+			diff.Storage[key] = statedb.GetState(addr, key)
+		}
+	}
+	return diff
+}
+
+// Trace executes each of cfg.Transactions in order against a single
+// state seeded from cfg.Accounts, and returns one ExecutionResult per
+// transaction in the order supplied.
+func Trace(cfg TraceConfig) ([]*ExecutionResult, error) {
+	chainConfig := cfg.ChainConfig
+	if chainConfig == nil {
+		chainConfig = params.AllEthashProtocolChanges
+	}
+
+	statedb, err := newStateDB(cfg.Accounts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build state: %w", err)
+	}
+
+	// watched tracks the storage keys that were populated in the
+	// pre-state, so PostState reports the same slots back even if the
+	// transaction itself never touched them.
+	watched := make(map[common.Address][]common.Hash, len(cfg.Accounts))
+	for addr, account := range cfg.Accounts {
+		for key := range account.Storage {
+			watched[addr] = append(watched[addr], key)
+		}
+	}
+
+	// baseFee is pinned at zero rather than threaded through TraceConfig,
+	// so every transaction in this trace sees the same value both here
+	// and in blockCtx below.
+	baseFee := big.NewInt(0)
+
+	results := make([]*ExecutionResult, 0, len(cfg.Transactions))
+	for _, tx := range cfg.Transactions {
+		msg, err := tx.toMessage(chainConfig, baseFee)
+		if err != nil {
+			return nil, err
+		}
+
+		// With no Logger supplied, fall back to buffering a StructLog
+		// slice on ExecutionResult, as Trace has always done.
+		var structLogger *vm.StructLogger
+		tracer := cfg.Logger
+		if tracer == nil {
+			structLogger = vm.NewStructLogger(nil)
+			tracer = structLogger
+		}
+
+		blockCtx := vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: big.NewInt(1),
+			Time:        big.NewInt(0),
+			Difficulty:  big.NewInt(0),
+			// BaseFee must be non-nil once the chain config has London
+			// activated (true of the default AllEthashProtocolChanges),
+			// or ApplyMessage's preCheck panics comparing against it.
+			BaseFee:  baseFee,
+			GasLimit: tx.GasLimit,
+		}
+		evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), statedb, chainConfig, vm.Config{Debug: true, Tracer: tracer})
+
+		// evm.Create derives the new contract's address from the sender's
+		// nonce before the call increments it, so it must be captured here
+		// rather than recomputed from statedb after ApplyMessage returns.
+		var createdAddr common.Address
+		if msg.To() == nil {
+			createdAddr = crypto.CreateAddress(msg.From(), statedb.GetNonce(msg.From()))
+		}
+
+		gasPool := new(core.GasPool).AddGas(msg.Gas())
+		applied, err := core.ApplyMessage(evm, msg, gasPool)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply tx: %w", err)
+		}
+		statedb.Finalise(true)
+
+		result := &ExecutionResult{
+			Gas:         applied.UsedGas,
+			Failed:      applied.Failed(),
+			ReturnValue: fmt.Sprintf("%x", applied.ReturnData),
+		}
+		if structLogger != nil {
+			result.StructLogs = structLogger.StructLogs()
+		}
+
+		postState := make(map[common.Address]AccountDiff, len(watched)+2)
+		postState[msg.From()] = snapshotAccount(statedb, msg.From(), watched[msg.From()])
+		if msg.To() != nil {
+			postState[*msg.To()] = snapshotAccount(statedb, *msg.To(), watched[*msg.To()])
+		} else {
+			postState[createdAddr] = snapshotAccount(statedb, createdAddr, watched[createdAddr])
+		}
+		for addr, keys := range watched {
+			if _, ok := postState[addr]; !ok {
+				postState[addr] = snapshotAccount(statedb, addr, keys)
+			}
+		}
+		result.PostState = postState
+
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,129 @@
+package gethutil
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestTraceSharesStateAcrossTransactions(t *testing.T) {
+	// Addresses below 0x0a collide with the precompiled contracts, so use
+	// addresses well outside that range.
+	sender := common.BytesToAddress([]byte{0x11})
+	receiver := common.BytesToAddress([]byte{0x22})
+
+	results, err := Trace(TraceConfig{
+		Accounts: map[common.Address]Account{
+			sender: {Balance: big.NewInt(1000)},
+		},
+		Transactions: []Transaction{
+			{From: sender, To: &receiver, Value: big.NewInt(100), GasLimit: 21000},
+			{From: sender, To: &receiver, Value: big.NewInt(100), GasLimit: 21000},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Trace returned an error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	receiverBalance := results[1].PostState[receiver].Balance
+	if receiverBalance.Cmp(big.NewInt(200)) != 0 {
+		t.Fatalf("expected receiver balance 200 after both transfers, got %s", receiverBalance)
+	}
+}
+
+func TestTraceSignsWithKeyAcrossTxTypes(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	receiver := common.BytesToAddress([]byte{0x22})
+	chainID := big.NewInt(1)
+
+	for _, txType := range []uint8{types.LegacyTxType, types.AccessListTxType, types.DynamicFeeTxType} {
+		results, err := Trace(TraceConfig{
+			Accounts: map[common.Address]Account{
+				from: {Balance: new(big.Int).Lsh(big.NewInt(1), 64)},
+			},
+			Transactions: []Transaction{{
+				To:         &receiver,
+				GasLimit:   21000,
+				GasPrice:   big.NewInt(1),
+				GasFeeCap:  big.NewInt(1),
+				GasTipCap:  big.NewInt(1),
+				ChainID:    chainID,
+				Type:       txType,
+				PrivateKey: key,
+			}},
+		})
+		if err != nil {
+			t.Fatalf("tx type %d: Trace returned an error: %v", txType, err)
+		}
+		if results[0].PostState[from].Nonce != 1 {
+			t.Fatalf("tx type %d: expected sender nonce 1 after signed tx, got %d", txType, results[0].PostState[from].Nonce)
+		}
+	}
+}
+
+func TestTraceChargesEffectiveGasPriceForDynamicFeeTx(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	receiver := common.BytesToAddress([]byte{0x22})
+	startBalance := new(big.Int).Lsh(big.NewInt(1), 64)
+
+	results, err := Trace(TraceConfig{
+		Accounts: map[common.Address]Account{
+			from: {Balance: startBalance},
+		},
+		Transactions: []Transaction{{
+			To:         &receiver,
+			GasLimit:   21000,
+			GasFeeCap:  big.NewInt(1000),
+			GasTipCap:  big.NewInt(1),
+			ChainID:    big.NewInt(1),
+			Type:       types.DynamicFeeTxType,
+			PrivateKey: key,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Trace returned an error: %v", err)
+	}
+
+	// Trace pins BlockContext.BaseFee at 0, so the effective gas price is
+	// min(GasFeeCap, GasTipCap+BaseFee) = 1, not the full GasFeeCap of 1000.
+	want := new(big.Int).Sub(startBalance, big.NewInt(21000))
+	got := results[0].PostState[from].Balance
+	if got.Cmp(want) != 0 {
+		t.Fatalf("expected sender balance %s after paying the effective gas price, got %s", want, got)
+	}
+}
+
+func TestTraceReportsCreatedContractInPostState(t *testing.T) {
+	sender := common.BytesToAddress([]byte{0x11})
+
+	results, err := Trace(TraceConfig{
+		Accounts: map[common.Address]Account{
+			sender: {Balance: big.NewInt(1000)},
+		},
+		Transactions: []Transaction{
+			{From: sender, Data: []byte{0x00}, GasLimit: 100000},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Trace returned an error: %v", err)
+	}
+
+	created := crypto.CreateAddress(sender, 0)
+	if _, ok := results[0].PostState[created]; !ok {
+		t.Fatalf("expected created contract %s in PostState, got %+v", created, results[0].PostState)
+	}
+}